@@ -0,0 +1,68 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// serverMetrics holds the RED instruments emitted alongside the trace.Span by
+// TraceWithOptions.
+type serverMetrics struct {
+	requestDuration metric.Float64Histogram
+	activeRequests  metric.Int64UpDownCounter
+	requestBodySize metric.Int64Histogram
+}
+
+// newServerMetrics lazily creates the request duration, active request and request body
+// size instruments from mp. Instrument creation errors are reported through otel.Handle; the
+// returned instruments are still usable no-ops in that case, matching the rest of the SDK's
+// fail-open behavior.
+func newServerMetrics(mp metric.MeterProvider) *serverMetrics {
+	meter := mp.Meter("github.com/vincentfree/opentelemetry/otelmiddleware", metric.WithInstrumentationVersion(version))
+
+	requestDuration, err := meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Duration of inbound HTTP requests."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	activeRequests, err := meter.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithDescription("Number of in-flight inbound HTTP requests."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	requestBodySize, err := meter.Int64Histogram(
+		"http.server.request.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of inbound HTTP request bodies."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &serverMetrics{
+		requestDuration: requestDuration,
+		activeRequests:  activeRequests,
+		requestBodySize: requestBodySize,
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectMetricNames collects everything recorded so far from reader and returns the
+// metrics, keyed by instrument name for easy lookup.
+func collectMetricNames(t *testing.T, reader *metric.ManualReader) map[string]metricdata.Metrics {
+	t.Helper()
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	byName := map[string]metricdata.Metrics{}
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			byName[m.Name] = m
+		}
+	}
+	return byName
+}
+
+func TestTraceWithOptions_RecordsRequestMetrics(t *testing.T) {
+	reader := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(reader))
+
+	handler := TraceWithOptions(WithMeterProvider(mp))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/widgets", nil))
+
+	metrics := collectMetricNames(t, reader)
+	for _, name := range []string{
+		"http.server.request.duration",
+		"http.server.active_requests",
+		"http.server.request.body.size",
+	} {
+		if _, ok := metrics[name]; !ok {
+			t.Errorf("expected instrument %q to be recorded, got %v", name, metrics)
+		}
+	}
+}
+
+func TestTraceWithOptions_TagsRouteWithoutMethod(t *testing.T) {
+	reader := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(reader))
+
+	handler := TraceWithOptions(WithMeterProvider(mp))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/widgets", nil))
+
+	metrics := collectMetricNames(t, reader)
+	duration, ok := metrics["http.server.request.duration"]
+	if !ok {
+		t.Fatal("expected http.server.request.duration to be recorded")
+	}
+	hist, ok := duration.Data.(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) == 0 {
+		t.Fatalf("expected http.server.request.duration to be a non-empty histogram, got %#v", duration.Data)
+	}
+
+	route, ok := hist.DataPoints[0].Attributes.Value(attribute.Key("http.route"))
+	if !ok {
+		t.Fatal("expected http.route attribute on the recorded data point")
+	}
+	if got, want := route.AsString(), "/widgets"; got != want {
+		t.Errorf("expected http.route=%q (no method prefix), got %q", want, got)
+	}
+}
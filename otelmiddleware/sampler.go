@@ -0,0 +1,64 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// forceSampleKey is the context key a Sampler hook uses to mark a RecordAndSample decision so
+// that NewSampler's ShouldSample can honor it; trace.WithNewRoot alone only detaches the span
+// from its parent, it does not influence the TracerProvider's own sampling decision.
+type forceSampleKey struct{}
+
+// withForceSample marks ctx so a Sampler installed via NewSampler forces a RecordAndSample
+// decision for the span about to be started from it.
+func withForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+// NewSampler wraps fallback with logic that honors a RecordAndSample decision made by a
+// Sampler passed to WithSampler. Install the result as the TracerProvider's sampler, e.g. via
+// sdktrace.WithSampler(otelmiddleware.NewSampler(sdktrace.TraceIDRatioBased(0.1))), so a
+// WithSampler hook that returns RecordAndSample isn't silently overridden by fallback.
+func NewSampler(fallback sdktrace.Sampler) sdktrace.Sampler {
+	return &forcingSampler{fallback: fallback}
+}
+
+// forcingSampler is the sdktrace.Sampler returned by NewSampler.
+type forcingSampler struct {
+	fallback sdktrace.Sampler
+}
+
+// ShouldSample forces a RecordAndSample decision when the parent context was marked via
+// withForceSample, otherwise it defers to the fallback sampler.
+func (s *forcingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if forced, _ := p.ParentContext.Value(forceSampleKey{}).(bool); forced {
+		psc := trace.SpanContextFromContext(p.ParentContext)
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: psc.TraceState(),
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description returns the Sampler description, embedding the fallback's own description.
+func (s *forcingSampler) Description() string {
+	return "OtelMiddlewareForcingSampler{fallback:" + s.fallback.Description() + "}"
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestForcingSampler_ForcesRecordAndSampleWhenMarked(t *testing.T) {
+	s := NewSampler(sdktrace.NeverSample())
+
+	ctx := withForceSample(context.Background())
+	result := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx})
+
+	if result.Decision != sdktrace.RecordAndSample {
+		t.Errorf("expected RecordAndSample when ctx was marked via withForceSample, got %v", result.Decision)
+	}
+}
+
+func TestForcingSampler_DefersToFallbackWithoutForceMark(t *testing.T) {
+	s := NewSampler(sdktrace.NeverSample())
+
+	result := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background()})
+
+	if result.Decision != sdktrace.Drop {
+		t.Errorf("expected the fallback sampler's Drop decision to apply for an unmarked ctx, got %v", result.Decision)
+	}
+}
+
+func TestForcingSampler_DescriptionEmbedsFallback(t *testing.T) {
+	fallback := sdktrace.AlwaysSample()
+	s := NewSampler(fallback)
+
+	if got, want := s.Description(), fallback.Description(); !strings.Contains(got, want) {
+		t.Errorf("expected Description %q to embed the fallback's description %q", got, want)
+	}
+}
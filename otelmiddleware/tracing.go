@@ -15,15 +15,28 @@
 package otelmiddleware
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.11.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// httpServerDurationKey is the span attribute used to record the wall-clock time taken to
+// serve a request, in milliseconds. This is not part of the stable HTTP semconv attribute
+// set, which reserves http.server.duration for the metrics histogram, but it is useful
+// alongside traces for backends without metric support.
+const httpServerDurationKey = attribute.Key("http.server.duration")
+
 // version is used as the instrumentation version.
 const version = "0.1.0"
 
@@ -31,12 +44,33 @@ const version = "0.1.0"
 // It can be passed to the TraceWithOptions function to configure a traceConfig struct.
 type TraceOption func(*traceConfig)
 
+// SpanNameFormatter builds the span name for an incoming request. It is called once per
+// request and replaces the default `METHOD /uri` naming scheme.
+type SpanNameFormatter func(r *http.Request) string
+
+// Filter decides whether a request should be traced at all. It is called before the
+// propagator extracts the incoming trace context and before the tracer starts a span, so a
+// request it rejects incurs none of the span-creation cost. This is typically used to skip
+// noisy health/readiness endpoints.
+type Filter func(r *http.Request) bool
+
+// Sampler makes a per-request head-sampling decision before the span is started. It
+// complements the TracerProvider's own sampler and is typically used for URL-based sampling
+// rules, e.g. always sampling a specific route regardless of the global sampling ratio. A
+// RecordAndSample decision only takes effect if the TracerProvider's sampler is, or wraps,
+// one created by NewSampler; otherwise the TracerProvider's own sampler still decides.
+type Sampler func(r *http.Request) sdktrace.SamplingDecision
+
 // traceConfig contains all the configuration for the library.
 type traceConfig struct {
-	serviceName string
-	tracer      trace.Tracer
-	propagator  propagation.TextMapPropagator
-	attributes  []attribute.KeyValue
+	serviceName   string
+	tracer        trace.Tracer
+	propagator    propagation.TextMapPropagator
+	attributes    []attribute.KeyValue
+	nameFormatter SpanNameFormatter
+	meterProvider metric.MeterProvider
+	filter        Filter
+	sampler       Sampler
 }
 
 // TraceWithOptions takes TraceOption's and initializes a new trace.Span.
@@ -60,11 +94,24 @@ func TraceWithOptions(opt ...TraceOption) func(next http.Handler) http.Handler {
 	if config.serviceName == "" {
 		config.serviceName = "TracedApplication"
 	}
+	// check for the traceConfig.meterProvider if absent use the global one.
+	if config.meterProvider == nil {
+		config.meterProvider = otel.GetMeterProvider()
+	}
+	// lazily create the RED metric instruments emitted alongside every trace.Span.
+	metrics := newServerMetrics(config.meterProvider)
 	// the handler that initializes the trace.Span.
 	return func(next http.Handler) http.Handler {
 
 		// assign the handler which creates the OpenTelemetry trace.Span.
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			// a filter runs before the propagator extracts anything and before the tracer
+			// starts a span, so a filtered request incurs zero span-creation cost.
+			if config.filter != nil && !config.filter(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			requestCtx := r.Context()
 			// extract the OpenTelemetry span context from the context.Context object.
 			ctx := config.propagator.Extract(requestCtx, propagation.HeaderCarrier(r.Header))
@@ -82,36 +129,124 @@ func TraceWithOptions(opt ...TraceOption) func(next http.Handler) http.Handler {
 			if len(config.attributes) > 0 {
 				opts = append(opts, trace.WithAttributes(config.attributes...))
 			}
-			// extract the route name which is used for setting a usable name of the span.
-			spanName := extractRoute(r.RequestURI)
-			if spanName == "" {
-				// no path available
-				spanName = r.Proto + " " + r.Method + " /"
+			// a sampler hook makes a head-sampling decision before the span is started; a
+			// Drop decision skips span creation entirely like a Filter, while
+			// RecordAndSample forces a freshly sampled span via trace.WithNewRoot even if
+			// the incoming request carried an unsampled parent context.
+			if config.sampler != nil {
+				switch config.sampler(r) {
+				case sdktrace.Drop:
+					// ctx already carries whatever parent span context the propagator
+					// extracted above; pass it through so downstream code (including
+					// NewTransport) still sees the incoming trace.
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				case sdktrace.RecordAndSample:
+					ctx = withForceSample(ctx)
+					opts = append(opts, trace.WithNewRoot())
+				}
 			}
+			// determine the span name; a custom formatter takes precedence over the
+			// default `METHOD /uri` scheme, e.g. to use the matched route template and
+			// avoid the cardinality explosion raw URIs cause in trace backends.
+			var spanName string
+			if config.nameFormatter != nil {
+				spanName = config.nameFormatter(r)
+			} else {
+				// extract the route name which is used for setting a usable name of the span.
+				spanName = extractRoute(r.RequestURI)
+				if spanName == "" {
+					// no path available
+					spanName = r.Proto + " " + r.Method + " /"
+				}
 
-			// create a good name to recognize where the span originated.
-			spanName = r.Method + " /" + spanName
+				// create a good name to recognize where the span originated.
+				spanName = r.Method + " /" + spanName
+			}
 
 			// start the actual trace.Span.
 			ctx, span := config.tracer.Start(ctx, spanName, opts...)
 
 			defer span.End()
 
+			// record how long the handler takes to serve the request.
+			start := time.Now()
+
+			// metricAttrs tags every instrument recorded for this request. http.route holds
+			// only the route itself, not spanName, which already has the method folded in
+			// (and, with a custom SpanNameFormatter, may not be a route at all) -- mixing
+			// the two would defeat dashboards that group by method x route independently.
+			route := r.URL.Path
+			if route == "" {
+				route = "/"
+			}
+			metricAttrs := []attribute.KeyValue{
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+			}
+			metrics.activeRequests.Add(requestCtx, 1, metric.WithAttributes(metricAttrs...))
+			defer metrics.activeRequests.Add(requestCtx, -1, metric.WithAttributes(metricAttrs...))
+
 			// pass the span through the request context.
 			r = r.WithContext(ctx)
 			carrier := propagation.HeaderCarrier(r.Header)
 			otel.GetTextMapPropagator().Inject(ctx, carrier)
 
+			// wrap the request body to count bytes read when the Content-Length header is
+			// absent, e.g. for chunked transfer encoding.
+			reqBody := &countingReadCloser{ReadCloser: r.Body}
+			r.Body = reqBody
+
 			// use a wrapper for the http.responseWriter to capture the response status code;
 			// this information is added to the spans generated by the middleware
-			wrapperRes := NewWrapResponseWriter(w, r.ProtoMajor)
+			wrapperRes := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			// recover from panics raised by the wrapped handler so they surface as a recorded
+			// span event with a stack trace instead of silently crashing the trace; the panic
+			// is re-raised once the span has captured it so upstream recoverers still run.
+			defer func() {
+				if rec := recover(); rec != nil {
+					err := fmt.Errorf("panic: %v", rec)
+					span.RecordError(err, trace.WithStackTrace(true))
+					span.SetStatus(codes.Error, err.Error())
+					panic(rec)
+				}
+			}()
 
 			// serve the request to the next middleware.
 			next.ServeHTTP(wrapperRes, r)
+
+			status := wrapperRes.Status()
+			// prefer the advertised Content-Length, falling back to the bytes actually read
+			// from the body when it is unknown, e.g. for chunked requests.
+			reqContentLength := r.ContentLength
+			if reqContentLength < 0 {
+				reqContentLength = reqBody.n
+			}
+			duration := time.Since(start)
+
 			// add the response status code to the span
 			if span.IsRecording() {
-				span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(wrapperRes.Status())...)
+				span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+				// a 5xx response is a server-side failure per the OpenTelemetry HTTP semantic
+				// conventions, so mark the span accordingly; 4xx is a client error and the
+				// server span status is left unset.
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+
+				span.SetAttributes(
+					semconv.HTTPRequestContentLengthKey.Int64(reqContentLength),
+					semconv.HTTPResponseContentLengthKey.Int64(int64(wrapperRes.BytesWritten())),
+					httpServerDurationKey.Int64(duration.Milliseconds()),
+				)
 			}
+
+			// emit the RED metrics for this request regardless of the span's sampling
+			// decision, tagged with the method, route and response status code.
+			recordAttrs := append(metricAttrs, semconv.HTTPStatusCodeKey.Int(status))
+			metrics.requestDuration.Record(requestCtx, float64(duration.Milliseconds()), metric.WithAttributes(recordAttrs...))
+			metrics.requestBodySize.Record(requestCtx, reqContentLength, metric.WithAttributes(recordAttrs...))
 		}
 
 		return http.HandlerFunc(fn)
@@ -128,6 +263,19 @@ func extractRoute(uri string) string {
 	return uri[1:]
 }
 
+// countingReadCloser wraps a http.Request's Body to count the bytes read from it, used to
+// derive http.request_content_length when the Content-Length header is absent.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // WithTracer is a TraceOption to inject your own trace.Tracer.
 func WithTracer(tracer trace.Tracer) TraceOption {
 	return func(c *traceConfig) {
@@ -156,3 +304,39 @@ func WithAttributes(attributes ...attribute.KeyValue) TraceOption {
 		c.attributes = attributes
 	}
 }
+
+// WithSpanNameFormatter is a TraceOption to override the default `METHOD /uri` span naming
+// scheme with a custom SpanNameFormatter, e.g. one that uses the matched route template from
+// a router like chi or gorilla instead of the raw request URI.
+func WithSpanNameFormatter(formatter SpanNameFormatter) TraceOption {
+	return func(c *traceConfig) {
+		c.nameFormatter = formatter
+	}
+}
+
+// WithMeterProvider is a TraceOption to inject your own metric.MeterProvider. It is used to
+// emit the http.server.request.duration, http.server.active_requests and
+// http.server.request.body.size instruments alongside the trace.Span.
+func WithMeterProvider(mp metric.MeterProvider) TraceOption {
+	return func(c *traceConfig) {
+		c.meterProvider = mp
+	}
+}
+
+// WithFilter is a TraceOption to skip tracing entirely for requests that don't match f, e.g.
+// health or readiness endpoints that would otherwise flood a trace backend with noise.
+func WithFilter(f Filter) TraceOption {
+	return func(c *traceConfig) {
+		c.filter = f
+	}
+}
+
+// WithSampler is a TraceOption to apply a per-request head-sampling decision before the
+// tracer starts a span, on top of whatever sampler the TracerProvider itself is configured
+// with. A RecordAndSample decision is only enforced if the TracerProvider was built with
+// NewSampler; see its doc comment.
+func WithSampler(s Sampler) TraceOption {
+	return func(c *traceConfig) {
+		c.sampler = s
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.11.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport is a http.RoundTripper that starts a SpanKindClient trace.Span for every
+// outgoing request it handles, using the same TraceOption configuration as TraceWithOptions.
+type transport struct {
+	base   http.RoundTripper
+	config *traceConfig
+}
+
+// NewTransport wraps base in a http.RoundTripper that traces outgoing requests. It starts a
+// SpanKindClient span per request, injects the current context into the request headers via
+// the configured propagator, and records the http.method, http.url and http.status_code
+// semconv attributes before finishing the span on response. It accepts the same TraceOption's
+// as TraceWithOptions, so tracer, propagator, service name, extra attributes, WithFilter and
+// WithSampler all configure identically on both the client and server side. WithMeterProvider
+// is accepted but currently ignored here: NewTransport does not emit client-side metrics. If
+// base is nil, http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, opt ...TraceOption) http.RoundTripper {
+	// initialize an empty traceConfig.
+	config := &traceConfig{}
+
+	// apply the configuration passed to the function.
+	for _, o := range opt {
+		o(config)
+	}
+	// check for the traceConfig.tracer if absent use a default value.
+	if config.tracer == nil {
+		config.tracer = otel.Tracer("github.com/vincentfree/opentelemetry/otelmiddleware", trace.WithInstrumentationVersion(version))
+	}
+	// check for the traceConfig.propagator if absent use a default value.
+	if config.propagator == nil {
+		config.propagator = otel.GetTextMapPropagator()
+	}
+	// check for the traceConfig.serviceName if absent use a default value.
+	if config.serviceName == "" {
+		config.serviceName = "TracedApplication"
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{
+		base:   base,
+		config: config,
+	}
+}
+
+// RoundTrip starts a client span around the request, injects the trace context into its
+// headers, delegates to the base http.RoundTripper and then records the response status on
+// the span.
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// a filter runs before the tracer starts a span, so a filtered request incurs zero
+	// span-creation cost, matching TraceWithOptions.
+	if t.config.filter != nil && !t.config.filter(r) {
+		return t.base.RoundTrip(r)
+	}
+
+	ctx := r.Context()
+
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", r)...),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(r)...),
+		trace.WithSpanKind(trace.SpanKindClient),
+	}
+	// check for the traceConfig.attributes if present apply them to the trace.Span.
+	if len(t.config.attributes) > 0 {
+		opts = append(opts, trace.WithAttributes(t.config.attributes...))
+	}
+	// a sampler hook makes a head-sampling decision before the span is started; see
+	// TraceWithOptions for the matching server-side behavior.
+	if t.config.sampler != nil {
+		switch t.config.sampler(r) {
+		case sdktrace.Drop:
+			return t.base.RoundTrip(r)
+		case sdktrace.RecordAndSample:
+			ctx = withForceSample(ctx)
+			opts = append(opts, trace.WithNewRoot())
+		}
+	}
+
+	spanName := r.Method + " " + r.URL.Path
+
+	ctx, span := t.config.tracer.Start(ctx, spanName, opts...)
+	defer span.End()
+
+	// clone the request with the span context and inject it into the outgoing headers.
+	r = r.Clone(ctx)
+	t.config.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	if span.IsRecording() {
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(resp.StatusCode)...)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+
+	return resp, nil
+}
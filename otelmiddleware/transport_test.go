@@ -0,0 +1,145 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// roundTripFunc adapts a func to a http.RoundTripper, standing in for the real transport.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newResponse(status int) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(http.NoBody)}
+}
+
+func TestNewTransport_StartsClientSpanAndInjectsHeaders(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	var gotHeader string
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotHeader = r.Header.Get("traceparent")
+		return newResponse(http.StatusOK), nil
+	})
+
+	rt := NewTransport(base, WithTracer(tracer), WithPropagator(propagation.TraceContext{}))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/widgets", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	if gotHeader == "" {
+		t.Error("expected the traceparent header to be injected into the outgoing request")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].SpanKind != trace.SpanKindClient {
+		t.Errorf("expected SpanKindClient, got %v", spans[0].SpanKind)
+	}
+}
+
+func TestNewTransport_ServerErrorSetsSpanStatus(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return newResponse(http.StatusInternalServerError), nil
+	})
+
+	rt := NewTransport(base, WithTracer(tracer))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/widgets", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].Status.Code; got != codes.Error {
+		t.Errorf("expected span status Error for a 500 response, got %v", got)
+	}
+}
+
+func TestNewTransport_FilterSkipsSpanCreation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	var baseCalled bool
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		baseCalled = true
+		return newResponse(http.StatusOK), nil
+	})
+
+	rt := NewTransport(base, WithTracer(tracer), WithFilter(func(*http.Request) bool { return false }))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/healthz", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	if !baseCalled {
+		t.Error("expected the base RoundTripper to still be called for a filtered request")
+	}
+	if len(exporter.GetSpans()) != 0 {
+		t.Errorf("expected no span for a filtered request, got %d", len(exporter.GetSpans()))
+	}
+}
+
+func TestNewTransport_SamplerDropSkipsSpanCreation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	var baseCalled bool
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		baseCalled = true
+		return newResponse(http.StatusOK), nil
+	})
+
+	rt := NewTransport(base, WithTracer(tracer), WithSampler(func(*http.Request) sdktrace.SamplingDecision {
+		return sdktrace.Drop
+	}))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/widgets", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	if !baseCalled {
+		t.Error("expected the base RoundTripper to still be called when the sampler drops the request")
+	}
+	if len(exporter.GetSpans()) != 0 {
+		t.Errorf("expected no span when the sampler drops the request, got %d", len(exporter.GetSpans()))
+	}
+}
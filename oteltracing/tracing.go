@@ -0,0 +1,176 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oteltracing provides a one-call bootstrap for the OpenTelemetry tracing SDK, so
+// consumers of otelmiddleware don't have to hand-assemble a TracerProvider, exporter and
+// propagators before the middleware produces anything useful.
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.11.0"
+)
+
+// Protocol selects the wire protocol used by the OTLP exporter.
+type Protocol string
+
+const (
+	// ProtocolGRPC exports spans over OTLP/gRPC using otlptracegrpc.
+	ProtocolGRPC Protocol = "grpc"
+	// ProtocolHTTP exports spans over OTLP/HTTP using otlptracehttp.
+	ProtocolHTTP Protocol = "http"
+)
+
+// Sampler selects the trace.Sampler used by the TracerProvider.
+type Sampler string
+
+const (
+	// SamplerAlwaysOn samples every trace.
+	SamplerAlwaysOn Sampler = "always_on"
+	// SamplerTraceIDRatio samples a fraction of traces, configured via Config.SamplerArg.
+	SamplerTraceIDRatio Sampler = "trace_id_ratio"
+	// SamplerParentBased defers to the parent span's sampling decision, falling back to
+	// SamplerAlwaysOn for root spans.
+	SamplerParentBased Sampler = "parent_based"
+)
+
+// Config configures the TracerProvider created by Init. Fields left at their zero value fall
+// back to the standard OTEL_EXPORTER_OTLP_* environment variables where the underlying
+// exporter supports it, or to a sane default otherwise.
+type Config struct {
+	// ServiceName is recorded as the service.name resource attribute.
+	ServiceName string
+	// ServiceVersion is recorded as the service.version resource attribute.
+	ServiceVersion string
+	// ServiceInstanceID is recorded as the service.instance.id resource attribute.
+	ServiceInstanceID string
+	// Environment is recorded as the deployment.environment resource attribute.
+	Environment string
+	// Protocol selects the OTLP transport. Defaults to ProtocolGRPC.
+	Protocol Protocol
+	// Endpoint is passed to the exporter as its collector endpoint. If empty, the exporter
+	// falls back to OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_TRACES_ENDPOINT.
+	Endpoint string
+	// Insecure disables TLS on the OTLP connection.
+	Insecure bool
+	// Sampler selects the sampling strategy. Defaults to SamplerParentBased.
+	Sampler Sampler
+	// SamplerArg is the sampling ratio used by SamplerTraceIDRatio, in the range [0, 1].
+	SamplerArg float64
+}
+
+// Init configures global W3C TraceContext + Baggage propagators and a TracerProvider backed
+// by a batch span processor and an OTLP exporter, then installs the provider as the global
+// otel.TracerProvider. The returned shutdown func flushes and closes the exporter and must be
+// called, typically via defer, before the process exits.
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("oteltracing: failed to create exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(resourceAttributes(cfg)...),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oteltracing: failed to create resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp.Shutdown, nil
+}
+
+// newExporter creates the OTLP trace exporter for the protocol selected by cfg, falling back
+// to the standard OTEL_EXPORTER_OTLP_* environment variables for anything left unset.
+func newExporter(ctx context.Context, cfg Config) (*otlptrace.Exporter, error) {
+	switch cfg.Protocol {
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ProtocolGRPC, "":
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("oteltracing: unknown protocol %q", cfg.Protocol)
+	}
+}
+
+// newSampler translates cfg.Sampler into an sdktrace.Sampler, defaulting to a parent-based
+// always-on sampler when unset.
+func newSampler(cfg Config) sdktrace.Sampler {
+	switch cfg.Sampler {
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample()
+	case SamplerTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerArg))
+	case SamplerParentBased, "":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	default:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+}
+
+// resourceAttributes builds the service.* resource attributes supplied via cfg; empty fields
+// are omitted so resource.WithFromEnv can fill them in instead.
+func resourceAttributes(cfg Config) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if cfg.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceNameKey.String(cfg.ServiceName))
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(cfg.ServiceVersion))
+	}
+	if cfg.ServiceInstanceID != "" {
+		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(cfg.ServiceInstanceID))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(cfg.Environment))
+	}
+	return attrs
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 Vincent Free
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oteltracing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.11.0"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantContains string
+	}{
+		{"always_on", Config{Sampler: SamplerAlwaysOn}, "AlwaysOnSampler"},
+		{"trace_id_ratio", Config{Sampler: SamplerTraceIDRatio, SamplerArg: 0.25}, "TraceIDRatioBased{0.25}"},
+		{"parent_based_explicit", Config{Sampler: SamplerParentBased}, "ParentBased"},
+		{"unset_defaults_to_parent_based", Config{}, "ParentBased"},
+		{"unknown_falls_back_to_parent_based", Config{Sampler: "bogus"}, "ParentBased"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newSampler(tt.cfg).Description()
+			if !strings.Contains(got, tt.wantContains) {
+				t.Errorf("newSampler(%+v).Description() = %q, want it to contain %q", tt.cfg, got, tt.wantContains)
+			}
+		})
+	}
+}
+
+func TestResourceAttributes(t *testing.T) {
+	cfg := Config{
+		ServiceName:       "checkout",
+		ServiceVersion:    "1.2.3",
+		ServiceInstanceID: "instance-1",
+		Environment:       "staging",
+	}
+
+	want := []attribute.KeyValue{
+		semconv.ServiceNameKey.String("checkout"),
+		semconv.ServiceVersionKey.String("1.2.3"),
+		semconv.ServiceInstanceIDKey.String("instance-1"),
+		semconv.DeploymentEnvironmentKey.String("staging"),
+	}
+
+	if got := resourceAttributes(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("resourceAttributes(%+v) = %v, want %v", cfg, got, want)
+	}
+}
+
+func TestResourceAttributes_OmitsEmptyFields(t *testing.T) {
+	if got := resourceAttributes(Config{}); len(got) != 0 {
+		t.Errorf("resourceAttributes(Config{}) = %v, want no attributes so resource.WithFromEnv can fill them in", got)
+	}
+
+	if got := resourceAttributes(Config{ServiceName: "checkout"}); len(got) != 1 {
+		t.Errorf("resourceAttributes with only ServiceName set = %v, want exactly 1 attribute", got)
+	}
+}